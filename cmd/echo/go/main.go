@@ -44,6 +44,12 @@ func handleConn(conn net.Conn) {
 			}
 			return
 		}
+
+		// Latency starts once a request has actually arrived, not
+		// while we're idle waiting on the client between requests —
+		// otherwise it would measure client think time, not echo
+		// round-trip time.
+		start := time.Now()
 		atomic.AddUint64(&bytesIn, uint64(n))
 
 		written := 0
@@ -56,6 +62,7 @@ func handleConn(conn net.Conn) {
 			written += w
 		}
 		atomic.AddUint64(&bytesOut, uint64(n))
+		recordLatency(time.Since(start))
 	}
 }
 
@@ -79,14 +86,21 @@ func main() {
 		for {
 			time.Sleep(5 * time.Second)
 			elapsed := time.Since(start).Seconds()
+
+			buckets, total := histSnapshot()
+
 			fmt.Fprintf(os.Stderr,
-				"[%.1fs] active=%d accepts=%d bytes_in=%d bytes_out=%d err=%d\n",
+				"[%.1fs] active=%d accepts=%d bytes_in=%d bytes_out=%d err=%d p50=%s p95=%s p99=%s p999=%s\n",
 				elapsed,
 				atomic.LoadInt64(&active),
 				atomic.LoadUint64(&accepts),
 				atomic.LoadUint64(&bytesIn),
 				atomic.LoadUint64(&bytesOut),
 				atomic.LoadUint64(&errors),
+				percentile(buckets, total, 50),
+				percentile(buckets, total, 95),
+				percentile(buckets, total, 99),
+				percentile(buckets, total, 99.9),
 			)
 		}
 	}()
@@ -97,6 +111,14 @@ func main() {
 	go func() {
 		<-sigCh
 		fmt.Fprintf(os.Stderr, "\ngo-echo: shutting down...\n")
+		buckets, total := histSnapshot()
+		fmt.Fprintf(os.Stderr, "go-echo: final echo round-trip latency (n=%d) p50=%s p95=%s p99=%s p999=%s\n",
+			total,
+			percentile(buckets, total, 50),
+			percentile(buckets, total, 95),
+			percentile(buckets, total, 99),
+			percentile(buckets, total, 99.9),
+		)
 		ln.Close()
 	}()
 
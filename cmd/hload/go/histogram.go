@@ -0,0 +1,120 @@
+// Lock-free latency histogram, shared by the service-time,
+// response-time, and per-connection histograms hload keeps.
+//
+// Logarithmic bucketing (HDR-style) over a 1µs–60s range using 2048
+// buckets, each about 0.9% wider than the one before. Each recorder
+// picks a shard via an
+// atomic round-robin counter, so there is no contention on a shared
+// bucket array under load; snapshot merges the shards when a reading
+// is needed.
+
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	histMinNanos  = float64(time.Microsecond)
+	histMaxNanos  = float64(60 * time.Second)
+	histBuckets   = 2048
+	histNumShards = 64
+)
+
+var histScale = float64(histBuckets-1) / math.Log(histMaxNanos/histMinNanos)
+
+type histShard struct {
+	buckets [histBuckets]uint64
+}
+
+// histogram is an instantiable HDR-style latency histogram. hload
+// keeps several of these (service time, response time, one per
+// connection), so each needs its own shards rather than a shared
+// global.
+type histogram struct {
+	shards   [histNumShards]histShard
+	shardSeq uint64
+}
+
+func (h *histogram) record(d time.Duration) {
+	idx := bucketFor(float64(d))
+	shard := atomic.AddUint64(&h.shardSeq, 1) % histNumShards
+	atomic.AddUint64(&h.shards[shard].buckets[idx], 1)
+}
+
+func bucketFor(nanos float64) int {
+	if nanos < histMinNanos {
+		nanos = histMinNanos
+	}
+	idx := int(math.Log(nanos/histMinNanos) * histScale)
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= histBuckets {
+		idx = histBuckets - 1
+	}
+	return idx
+}
+
+func nanosFor(bucket int) float64 {
+	return histMinNanos * math.Exp(float64(bucket)/histScale)
+}
+
+// snapshot merges all shards into one cumulative bucket array and
+// returns the total sample count.
+func (h *histogram) snapshot() (buckets [histBuckets]uint64, total uint64) {
+	for s := range h.shards {
+		for i := range h.shards[s].buckets {
+			buckets[i] += atomic.LoadUint64(&h.shards[s].buckets[i])
+		}
+	}
+	for _, v := range buckets {
+		total += v
+	}
+	return buckets, total
+}
+
+// connHistogram is a single-shard latency histogram for the
+// per-connection breakdown. Unlike histogram, each one has exactly
+// one writer (its own connection's worker goroutine), so there's no
+// contention to shard away — sharding it the same way as the shared
+// service/response histograms would cost 64x the memory and a 64x
+// costlier snapshot() for no benefit, and that cost scales with
+// -conns.
+type connHistogram struct {
+	buckets [histBuckets]uint64
+}
+
+func (h *connHistogram) record(d time.Duration) {
+	atomic.AddUint64(&h.buckets[bucketFor(float64(d))], 1)
+}
+
+func (h *connHistogram) snapshot() (buckets [histBuckets]uint64, total uint64) {
+	for i := range h.buckets {
+		buckets[i] = atomic.LoadUint64(&h.buckets[i])
+		total += buckets[i]
+	}
+	return buckets, total
+}
+
+// percentile returns the latency at the given percentile (0-100] from
+// a merged bucket snapshot.
+func percentile(buckets [histBuckets]uint64, total uint64, p float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p / 100 * float64(total)))
+	if target == 0 {
+		target = 1
+	}
+	var cum uint64
+	for i, v := range buckets {
+		cum += v
+		if cum >= target {
+			return time.Duration(nanosFor(i))
+		}
+	}
+	return time.Duration(nanosFor(histBuckets - 1))
+}
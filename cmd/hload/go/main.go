@@ -0,0 +1,280 @@
+// hload: coordinated load generator for the cmd/httpd benchmark
+// servers. It speaks HTTP only, so it cannot drive the raw TCP
+// cmd/echo server.
+//
+// Modes (selected via -mode):
+//
+//   max  — each of -conns keep-alive connections fires its next
+//          request as soon as the previous response lands (closed
+//          loop). Reports max achievable throughput.
+//
+//   qps  — a single dispatcher schedules request arrivals as a
+//          Poisson process at -qps, independent of how fast responses
+//          come back (open loop). Slow responses queue instead of
+//          throttling the generator, so — unlike closed-loop tools
+//          such as ab/wrk — a slowdown shows up as queueing delay
+//          rather than being hidden by coordinated omission.
+//
+// For each request it records both:
+//
+//   service time  — time from actually sending the request to
+//                   receiving the response (what the server saw)
+//   response time — time from the request's *scheduled* arrival to
+//                   the response (what an open-loop client actually
+//                   experienced, queueing included)
+//
+// -sweep steps -qps from -sweep-from to -sweep-to in -sweep-step
+// increments, running each step for -sweep-step-duration, and writes
+// a (qps, p50, p99, p999, error_rate, missed_schedule_rate) CSV to
+// -csv for plotting.
+//
+// Build: cd cmd/hload/go && go build -o hload .
+// Run:   ./hload -addr http://127.0.0.1:8083/ -mode qps -qps 5000
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "http://127.0.0.1:8083/", "target URL")
+	conns := flag.Int("conns", 50, "number of keep-alive connections/workers")
+	mode := flag.String("mode", "max", "client mode: max|qps")
+	qps := flag.Float64("qps", 1000, "target QPS for -mode=qps (open-loop, Poisson arrivals)")
+	duration := flag.Duration("duration", 10*time.Second, "test duration")
+	sweep := flag.Bool("sweep", false, "sweep QPS from -sweep-from to -sweep-to instead of a single run")
+	sweepFrom := flag.Float64("sweep-from", 1000, "sweep: starting QPS")
+	sweepTo := flag.Float64("sweep-to", 10000, "sweep: ending QPS")
+	sweepStep := flag.Float64("sweep-step", 1000, "sweep: QPS increment per step")
+	sweepStepDuration := flag.Duration("sweep-step-duration", 5*time.Second, "sweep: duration of each QPS step")
+	csvPath := flag.String("csv", "", "sweep: write (qps,p50,p99,p999,error_rate,missed_schedule_rate) rows here")
+	flag.Parse()
+
+	if *sweep {
+		runSweep(*addr, *conns, *sweepFrom, *sweepTo, *sweepStep, *sweepStepDuration, *csvPath)
+		return
+	}
+
+	r := runLoad(*addr, *conns, *mode, *qps, *duration)
+	printResult(os.Stderr, "run", *qps, r)
+}
+
+// result holds the outcome of one load run (or one sweep step).
+type result struct {
+	total           uint64
+	errors          uint64
+	missedSchedules uint64 // open-loop jobs dropped because all workers were busy
+	serviceHist     *histogram
+	responseHist    *histogram
+	connHists       []*connHistogram // per-connection response-time histograms
+}
+
+func (r *result) errorRate() float64 {
+	if r.total == 0 {
+		return 0
+	}
+	return float64(r.errors) / float64(r.total)
+}
+
+// missedScheduleRate is missed schedules as a fraction of all arrivals
+// the dispatcher scheduled (sent + missed), not just the sent ones.
+func (r *result) missedScheduleRate() float64 {
+	scheduled := r.total + r.missedSchedules
+	if scheduled == 0 {
+		return 0
+	}
+	return float64(r.missedSchedules) / float64(scheduled)
+}
+
+// runLoad drives -conns workers against addr for duration, either in
+// closed-loop max-throughput mode or open-loop fixed-QPS mode, and
+// returns the aggregated latency histograms.
+func runLoad(addr string, conns int, mode string, qps float64, duration time.Duration) *result {
+	client := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:        conns,
+			MaxIdleConnsPerHost: conns,
+		},
+	}
+
+	r := &result{
+		serviceHist:  &histogram{},
+		responseHist: &histogram{},
+		connHists:    make([]*connHistogram, conns),
+	}
+	for i := range r.connHists {
+		r.connHists[i] = &connHistogram{}
+	}
+
+	deadline := time.Now().Add(duration)
+
+	switch mode {
+	case "max":
+		runClosedLoop(client, addr, conns, deadline, r)
+	case "qps":
+		runOpenLoop(client, addr, conns, qps, deadline, r)
+	default:
+		fmt.Fprintf(os.Stderr, "hload: unknown mode %q (use max|qps)\n", mode)
+		os.Exit(1)
+	}
+
+	return r
+}
+
+// runClosedLoop has each of conns workers fire its next request as
+// soon as the previous response lands — the closed-loop, max-throughput
+// case. Service time and response time coincide here; there's no
+// queueing because nothing is scheduled ahead of the client.
+func runClosedLoop(client *http.Client, addr string, conns int, deadline time.Time, r *result) {
+	var wg sync.WaitGroup
+	for w := 0; w < conns; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				now := time.Now()
+				doRequest(client, addr, now, now, r, worker)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// runOpenLoop schedules request arrivals as a Poisson process at the
+// target QPS and hands each one to a pool of conns workers as soon as
+// a worker is free. A worker that's still busy when its next job is
+// due simply runs it late — the queueing delay shows up in response
+// time (scheduled arrival → response) even though service time
+// (send → response) looks fine. This is what exposes coordinated
+// omission: a closed-loop generator would never schedule that request
+// until the slow one finished, and so would never see the wait.
+//
+// The handoff to workers is non-blocking: if the job queue is full,
+// the dispatcher counts the arrival as missed and moves on rather than
+// blocking on the channel send. Blocking there would make the
+// dispatcher's own schedule slip under overload, which is exactly the
+// closed-loop, coordinated-omission-hiding behavior this generator
+// exists to avoid — so overload shows up as a rising missedSchedules
+// count instead of a silently throttled dispatcher.
+func runOpenLoop(client *http.Client, addr string, conns int, qps float64, deadline time.Time, r *result) {
+	type job struct{ scheduledAt time.Time }
+
+	jobs := make(chan job, 4096)
+	var wg sync.WaitGroup
+
+	for w := 0; w < conns; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := range jobs {
+				doRequest(client, addr, j.scheduledAt, time.Now(), r, worker)
+			}
+		}(w)
+	}
+
+	meanInterval := time.Duration(float64(time.Second) / qps)
+	next := time.Now()
+	for next.Before(deadline) {
+		time.Sleep(time.Until(next))
+		select {
+		case jobs <- job{scheduledAt: next}:
+		default:
+			atomic.AddUint64(&r.missedSchedules, 1)
+		}
+		// Exponential inter-arrival time => Poisson arrival process.
+		next = next.Add(time.Duration(rand.ExpFloat64() * float64(meanInterval)))
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// doRequest issues one GET, recording service time (sentAt → response)
+// into the service-time histogram, and response time (scheduledAt →
+// response) into the response-time and per-connection histograms.
+func doRequest(client *http.Client, addr string, scheduledAt, sentAt time.Time, r *result, worker int) {
+	atomic.AddUint64(&r.total, 1)
+
+	resp, err := client.Get(addr)
+	if err != nil {
+		atomic.AddUint64(&r.errors, 1)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	now := time.Now()
+	if resp.StatusCode >= 400 {
+		atomic.AddUint64(&r.errors, 1)
+	}
+
+	r.serviceHist.record(now.Sub(sentAt))
+	r.responseHist.record(now.Sub(scheduledAt))
+	r.connHists[worker].record(now.Sub(scheduledAt))
+}
+
+// printResult reports the aggregate and per-connection p99 summary
+// for one run or sweep step.
+func printResult(w io.Writer, label string, qps float64, r *result) {
+	svcBuckets, svcTotal := r.serviceHist.snapshot()
+	respBuckets, respTotal := r.responseHist.snapshot()
+
+	fmt.Fprintf(w, "hload [%s] qps=%.0f n=%d errors=%d (%.3f%%) missed_schedules=%d (%.3f%%)\n",
+		label, qps, r.total, r.errors, r.errorRate()*100, r.missedSchedules, r.missedScheduleRate()*100)
+	fmt.Fprintf(w, "  service time:  p50=%s p95=%s p99=%s p999=%s\n",
+		percentile(svcBuckets, svcTotal, 50), percentile(svcBuckets, svcTotal, 95),
+		percentile(svcBuckets, svcTotal, 99), percentile(svcBuckets, svcTotal, 99.9))
+	fmt.Fprintf(w, "  response time: p50=%s p95=%s p99=%s p999=%s\n",
+		percentile(respBuckets, respTotal, 50), percentile(respBuckets, respTotal, 95),
+		percentile(respBuckets, respTotal, 99), percentile(respBuckets, respTotal, 99.9))
+
+	var worstConnP99 time.Duration
+	for _, h := range r.connHists {
+		buckets, total := h.snapshot()
+		p99 := percentile(buckets, total, 99)
+		if p99 > worstConnP99 {
+			worstConnP99 = p99
+		}
+	}
+	fmt.Fprintf(w, "  worst per-connection p99: %s\n", worstConnP99)
+}
+
+// runSweep steps QPS from from to to in step increments, running each
+// step for stepDuration, and writes a CSV of
+// (qps,p50,p99,p999,error_rate,missed_schedule_rate) to csvPath if set.
+func runSweep(addr string, conns int, from, to, step float64, stepDuration time.Duration, csvPath string) {
+	var csv *os.File
+	if csvPath != "" {
+		f, err := os.Create(csvPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "hload: create %s: %v\n", csvPath, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		csv = f
+		fmt.Fprintln(csv, "qps,p50_us,p99_us,p999_us,error_rate,missed_schedule_rate")
+	}
+
+	for qps := from; qps <= to; qps += step {
+		r := runLoad(addr, conns, "qps", qps, stepDuration)
+		printResult(os.Stderr, "sweep", qps, r)
+
+		if csv != nil {
+			buckets, total := r.responseHist.snapshot()
+			p50 := percentile(buckets, total, 50)
+			p99 := percentile(buckets, total, 99)
+			p999 := percentile(buckets, total, 99.9)
+			fmt.Fprintf(csv, "%.0f,%.1f,%.1f,%.1f,%.6f,%.6f\n",
+				qps, float64(p50.Microseconds()), float64(p99.Microseconds()),
+				float64(p999.Microseconds()), r.errorRate(), r.missedScheduleRate())
+		}
+	}
+}
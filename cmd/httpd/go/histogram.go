@@ -0,0 +1,94 @@
+// Lock-free latency histogram for the httpd benchmark variants.
+//
+// Logarithmic bucketing (HDR-style) over a 1µs–60s range using 2048
+// buckets, each about 0.9% wider than the one before. Each goroutine
+// records into one of
+// a fixed number of shards via an atomic round-robin counter, so there
+// is no contention on a shared bucket array under load; statsLoop
+// merges the shards when it wants a reading.
+
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	histMinNanos  = float64(time.Microsecond)
+	histMaxNanos  = float64(60 * time.Second)
+	histBuckets   = 2048
+	histNumShards = 64
+)
+
+var histScale = float64(histBuckets-1) / math.Log(histMaxNanos/histMinNanos)
+
+type histShard struct {
+	buckets [histBuckets]uint64
+}
+
+var (
+	histShards    [histNumShards]histShard
+	histShardNext uint64
+)
+
+// recordLatency adds one sample to the latency histogram. Safe for
+// concurrent use by any number of goroutines.
+func recordLatency(d time.Duration) {
+	idx := bucketFor(float64(d))
+	shard := atomic.AddUint64(&histShardNext, 1) % histNumShards
+	atomic.AddUint64(&histShards[shard].buckets[idx], 1)
+}
+
+func bucketFor(nanos float64) int {
+	if nanos < histMinNanos {
+		nanos = histMinNanos
+	}
+	idx := int(math.Log(nanos/histMinNanos) * histScale)
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= histBuckets {
+		idx = histBuckets - 1
+	}
+	return idx
+}
+
+func nanosFor(bucket int) float64 {
+	return histMinNanos * math.Exp(float64(bucket)/histScale)
+}
+
+// histSnapshot merges all shards into one cumulative bucket array and
+// returns the total sample count.
+func histSnapshot() (buckets [histBuckets]uint64, total uint64) {
+	for s := range histShards {
+		for i := range histShards[s].buckets {
+			buckets[i] += atomic.LoadUint64(&histShards[s].buckets[i])
+		}
+	}
+	for _, v := range buckets {
+		total += v
+	}
+	return buckets, total
+}
+
+// percentile returns the latency at the given percentile (0-100] from
+// a merged bucket snapshot.
+func percentile(buckets [histBuckets]uint64, total uint64, p float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p / 100 * float64(total)))
+	if target == 0 {
+		target = 1
+	}
+	var cum uint64
+	for i, v := range buckets {
+		cum += v
+		if cum >= target {
+			return time.Duration(nanosFor(i))
+		}
+	}
+	return time.Duration(nanosFor(histBuckets - 1))
+}
@@ -14,6 +14,23 @@
 //            Worker-pool model, not goroutine-per-conn.
 //            Reuses goroutines, zero-alloc header parsing.
 //
+//   h2     — stdlib net/http + golang.org/x/net/http2, TLS ALPN
+//            Stream-multiplexed: one goroutine per connection plus one
+//            per active stream, so a single conn can serve many
+//            concurrent requests. Compares against HTTP/1's
+//            goroutine-per-connection model on the same handler.
+//
+//   h2c    — same handler over cleartext HTTP/2 (no TLS, no ALPN)
+//            via golang.org/x/net/http2/h2c, for environments/load
+//            generators that can't do TLS.
+//
+//   preforked — naive's handler, but accepted across N SO_REUSEPORT
+//            listeners (one per prefork-listeners, default GOMAXPROCS)
+//            instead of one shared listener with one shared accept
+//            loop. Isolates how much of naive's ceiling is the single
+//            accept-mutex/listener rather than per-conn handling —
+//            fiber's Prefork:true does the same thing for its model.
+//
 // Build: cd cmd/httpd/go && go build -o httpd-server .
 // Run:   gvt_app_variant=naive gvt_app_port=8083 ./httpd-server
 
@@ -21,17 +38,22 @@ package main
 
 import (
 	"bufio"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
 	"strconv"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // ── Shared state ──
@@ -47,7 +69,12 @@ const helloBody = "Hello from Go!\n"
 
 func main() {
 	port := flag.Int("port", 8083, "Listen port")
-	variant := flag.String("variant", "naive", "Server variant: naive|mux|fiber")
+	variant := flag.String("variant", "naive", "Server variant: naive|mux|fiber|h2|h2c|preforked")
+	preforkListeners := flag.Int("prefork-listeners", 0, "Number of SO_REUSEPORT listeners for the preforked variant (0 = GOMAXPROCS)")
+	certFile := flag.String("tls-cert", "", "TLS certificate file (h2 variant)")
+	keyFile := flag.String("tls-key", "", "TLS key file (h2 variant)")
+	maxStreams := flag.Uint("h2-max-streams", 250, "HTTP/2 MaxConcurrentStreams per connection")
+	initWindow := flag.Int("h2-init-window", 1<<20, "HTTP/2 initial stream/connection flow-control window, in bytes")
 	flag.Parse()
 
 	// Bench-runner env vars override defaults
@@ -71,6 +98,16 @@ func main() {
 	// Stats printer
 	go statsLoop()
 
+	// Print a final latency summary on shutdown
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintf(os.Stderr, "\ngo-httpd: shutting down...\n")
+		printLatencySummary("final")
+		os.Exit(0)
+	}()
+
 	addr := fmt.Sprintf("0.0.0.0:%d", *port)
 
 	switch *variant {
@@ -80,13 +117,27 @@ func main() {
 		runMux(addr)
 	case "fiber":
 		runFiber(addr)
+	case "h2":
+		runH2(addr, *certFile, *keyFile, uint32(*maxStreams), int32(*initWindow))
+	case "h2c":
+		runH2C(addr, uint32(*maxStreams), int32(*initWindow))
+	case "preforked":
+		n := *preforkListeners
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		runPreforked(addr, n)
 	default:
-		fmt.Fprintf(os.Stderr, "go-httpd: unknown variant %q (use naive|mux|fiber)\n", *variant)
+		fmt.Fprintf(os.Stderr, "go-httpd: unknown variant %q (use naive|mux|fiber|h2|h2c|preforked)\n", *variant)
 		os.Exit(1)
 	}
 }
 
 // ── Stats ──
+//
+// RPS comes from the requests/responses counters as before; latency
+// percentiles come from the histogram in histogram.go, which every
+// variant's handler feeds via recordLatency.
 
 func statsLoop() {
 	start := time.Now()
@@ -96,12 +147,32 @@ func statsLoop() {
 		resp := atomic.LoadUint64(&responses)
 		delta := resp - lastResp
 		rps := float64(delta) / 5.0
-		fmt.Fprintf(os.Stderr, "[%.1fs] resp=%d rps=%.0f\n",
-			time.Since(start).Seconds(), resp, rps)
+
+		buckets, total := histSnapshot()
+		p50 := percentile(buckets, total, 50)
+		p95 := percentile(buckets, total, 95)
+		p99 := percentile(buckets, total, 99)
+		p999 := percentile(buckets, total, 99.9)
+
+		fmt.Fprintf(os.Stderr, "[%.1fs] resp=%d rps=%.0f p50=%s p95=%s p99=%s p999=%s\n",
+			time.Since(start).Seconds(), resp, rps, p50, p95, p99, p999)
 		lastResp = resp
 	}
 }
 
+// printLatencySummary prints a one-off percentile report, used for the
+// final shutdown summary.
+func printLatencySummary(label string) {
+	buckets, total := histSnapshot()
+	fmt.Fprintf(os.Stderr, "go-httpd: %s latency summary (n=%d) p50=%s p95=%s p99=%s p999=%s\n",
+		label, total,
+		percentile(buckets, total, 50),
+		percentile(buckets, total, 95),
+		percentile(buckets, total, 99),
+		percentile(buckets, total, 99.9),
+	)
+}
+
 // ════════════════════════════════════════════════════════════════════
 // Variant: naive — raw net.Listener, manual HTTP
 // ════════════════════════════════════════════════════════════════════
@@ -152,6 +223,12 @@ func handleNaive(conn net.Conn) {
 			return // EOF or error
 		}
 
+		// Latency starts once the request is fully read, matching
+		// mux/fiber/h2 where the handler only runs after parsing —
+		// otherwise keep-alive idle time between requests would get
+		// baked into the sample and the variants wouldn't be comparable.
+		start := time.Now()
+
 		atomic.AddUint64(&requests, 1)
 
 		// Write fixed response
@@ -161,6 +238,7 @@ func handleNaive(conn net.Conn) {
 		}
 
 		atomic.AddUint64(&responses, 1)
+		recordLatency(time.Since(start))
 	}
 }
 
@@ -200,12 +278,16 @@ func readUntilHeaderEnd(r *bufio.Reader) bool {
 //   - ServeMux routing
 //   - ResponseWriter with header map
 
-func runMux(addr string) {
+// newMuxHandler builds the ServeMux used by runMux. Split out so the
+// alloc-tracking harness in httpd_alloc_test.go can drive it directly
+// against an httptest.ResponseRecorder without starting a listener.
+func newMuxHandler() http.Handler {
 	helloBytes := []byte(helloBody)
 	contentLen := strconv.Itoa(len(helloBytes))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
 		atomic.AddUint64(&requests, 1)
 		w.Header().Set("Content-Type", "text/plain")
 		w.Header().Set("Connection", "keep-alive")
@@ -213,11 +295,15 @@ func runMux(addr string) {
 		w.Header().Set("Content-Length", contentLen)
 		w.Write(helloBytes)
 		atomic.AddUint64(&responses, 1)
+		recordLatency(time.Since(start))
 	})
+	return mux
+}
 
+func runMux(addr string) {
 	server := &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: newMuxHandler(),
 	}
 
 	fmt.Fprintf(os.Stderr, "go-httpd [mux]: listening on http://%s/\n", addr)
@@ -238,7 +324,10 @@ func runMux(addr string) {
 //   - Zero-alloc header/path parsing
 //   - Pre-allocated buffers
 
-func runFiber(addr string) {
+// newFiberApp builds the fiber.App used by runFiber. Split out so the
+// alloc-tracking harness in httpd_alloc_test.go can drive it directly
+// via app.Test without starting a listener.
+func newFiberApp() *fiber.App {
 	app := fiber.New(fiber.Config{
 		ServerHeader:          "go-httpd",
 		DisableStartupMessage: true,
@@ -248,18 +337,107 @@ func runFiber(addr string) {
 	helloBytes := []byte(helloBody)
 
 	app.Get("/", func(c *fiber.Ctx) error {
+		start := time.Now()
 		atomic.AddUint64(&requests, 1)
 		c.Set("Content-Type", "text/plain")
 		c.Set("Connection", "keep-alive")
 		err := c.Send(helloBytes)
 		atomic.AddUint64(&responses, 1)
+		recordLatency(time.Since(start))
 		return err
 	})
 
+	return app
+}
+
+func runFiber(addr string) {
+	app := newFiberApp()
+
 	fmt.Fprintf(os.Stderr, "go-httpd [fiber]: listening on http://%s/\n", addr)
 
 	if err := app.Listen(addr); err != nil {
 		fmt.Fprintf(os.Stderr, "go-httpd: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+// ════════════════════════════════════════════════════════════════════
+// Variant: h2 / h2c — HTTP/2 via golang.org/x/net/http2
+// ════════════════════════════════════════════════════════════════════
+//
+// Stream-multiplexed model: one goroutine per connection, plus one
+// goroutine per active stream (net/http2's Server spawns these
+// internally). Same hello handler and request/response counters as
+// mux, so statsLoop's RPS is comparable across variants — the point
+// is to see how multiplexing changes throughput/GOMAXPROCS behavior
+// versus one goroutine per connection serving its requests in turn.
+
+func h2Handler() http.Handler {
+	helloBytes := []byte(helloBody)
+	contentLen := strconv.Itoa(len(helloBytes))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		atomic.AddUint64(&requests, 1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Server", "go-httpd")
+		w.Header().Set("Content-Length", contentLen)
+		w.Write(helloBytes)
+		atomic.AddUint64(&responses, 1)
+		recordLatency(time.Since(start))
+	})
+	return mux
+}
+
+func runH2(addr, certFile, keyFile string, maxStreams uint32, initWindow int32) {
+	if certFile == "" || keyFile == "" {
+		fmt.Fprintf(os.Stderr, "go-httpd: h2 variant requires -tls-cert and -tls-key\n")
+		os.Exit(1)
+	}
+
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   h2Handler(),
+		TLSConfig: &tls.Config{NextProtos: []string{"h2", "http/1.1"}},
+	}
+
+	h2s := &http2.Server{
+		MaxConcurrentStreams:         maxStreams,
+		MaxUploadBufferPerStream:     initWindow,
+		MaxUploadBufferPerConnection: initWindow,
+	}
+	if err := http2.ConfigureServer(server, h2s); err != nil {
+		fmt.Fprintf(os.Stderr, "go-httpd: http2.ConfigureServer: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "go-httpd [h2]: listening on https://%s/ (maxStreams=%d initWindow=%d)\n",
+		addr, maxStreams, initWindow)
+
+	if err := server.ListenAndServeTLS(certFile, keyFile); err != nil {
+		fmt.Fprintf(os.Stderr, "go-httpd: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runH2C(addr string, maxStreams uint32, initWindow int32) {
+	h2s := &http2.Server{
+		MaxConcurrentStreams:         maxStreams,
+		MaxUploadBufferPerStream:     initWindow,
+		MaxUploadBufferPerConnection: initWindow,
+	}
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: h2c.NewHandler(h2Handler(), h2s),
+	}
+
+	fmt.Fprintf(os.Stderr, "go-httpd [h2c]: listening on http://%s/ (maxStreams=%d initWindow=%d)\n",
+		addr, maxStreams, initWindow)
+
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "go-httpd: %v\n", err)
+		os.Exit(1)
+	}
+}
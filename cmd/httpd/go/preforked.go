@@ -0,0 +1,78 @@
+// Variant: preforked — N independent SO_REUSEPORT listeners
+//
+// naive's single net.Listener has one accept loop: every inbound
+// connection funnels through one fd. preforked instead opens
+// `listeners` separate sockets on the same port with SO_REUSEPORT, so
+// the kernel load-balances new connections across them, and runs each
+// one's accept loop on its own locked OS thread. This isolates how
+// much of naive's ceiling comes from that single accept path versus
+// handleNaive itself — the same question fiber's Prefork:true answers
+// for the fasthttp worker-pool model.
+//
+// handleNaive is reused unchanged, and requests/responses/the latency
+// histogram are already process-global, so no separate stats
+// aggregation is needed here (unlike a true multi-process prefork,
+// where child processes would need a shared mmap page or a
+// unix-domain collector socket to produce one unified RPS number).
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func runPreforked(addr string, listeners int) {
+	if listeners < 1 {
+		listeners = 1
+	}
+
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		},
+	}
+
+	fmt.Fprintf(os.Stderr, "go-httpd [preforked]: listening on http://%s/ across %d SO_REUSEPORT listeners\n",
+		addr, listeners)
+
+	done := make(chan struct{})
+	for i := 0; i < listeners; i++ {
+		ln, err := lc.Listen(context.Background(), "tcp", addr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-httpd: listen (SO_REUSEPORT) #%d: %v\n", i, err)
+			os.Exit(1)
+		}
+		go acceptLoop(ln)
+	}
+	<-done // acceptLoop never returns; block forever
+}
+
+// acceptLoop runs one listener's accept loop on its own locked OS
+// thread, so its syscalls don't compete with other listeners' for a
+// goroutine-scheduler-managed thread.
+func acceptLoop(ln net.Listener) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			continue
+		}
+		go handleNaive(conn)
+	}
+}